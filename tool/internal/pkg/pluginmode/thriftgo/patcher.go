@@ -15,10 +15,15 @@
 package thriftgo
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"go/format"
 	"io/ioutil"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -29,6 +34,33 @@ import (
 	"github.com/cloudwego/kitex"
 )
 
+// manifestFileName is the fixed name of the opt-in manifest generated next to
+// the patched files when -manifest is set.
+const manifestFileName = "kitex_manifest.json"
+
+// manifestEntry records the provenance of a single generated file: which IDL
+// it came from, content hashes for both sides, the kitex version used, and
+// the import set Kitex resolved for it.
+type manifestEntry struct {
+	OutputPath   string            `json:"output_path"`
+	Source       string            `json:"source"`
+	IDLSha256    string            `json:"idl_sha256"`
+	GoSha256     string            `json:"go_sha256"`
+	KitexVersion string            `json:"kitex_version"`
+	Imports      map[string]string `json:"imports"`
+}
+
+// manifest is the top-level document written to kitex_manifest.json.
+type manifest struct {
+	Module string          `json:"module"`
+	Files  []manifestEntry `json:"files"`
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
 const kitexUnusedProtection = `
 // KitexUnusedProtection is used to prevent 'imported and not used' error.
 var KitexUnusedProtection = struct{}{}
@@ -43,9 +75,43 @@ type patcher struct {
 	module    string
 	copyIDL   bool
 
+	// noFieldPacking, set via the "no_field_packing" plugin parameter,
+	// disables size-class struct field packing and falls back to the legacy
+	// fixed-length-first ordering.
+	noFieldPacking bool
+
+	// manifest, set via the "manifest" plugin parameter, emits
+	// kitex_manifest.json alongside the patches recording the provenance of
+	// every generated file. GoSha256 is computed from a gofmt'd copy of the
+	// emitted Go, independent of whatever downstream formatting the patch
+	// Content itself goes through.
+	manifest bool
+
 	fileTpl *template.Template
 }
 
+// parseArgs applies boolean feature toggles passed to the plugin as thriftgo
+// PluginParameters, e.g. "manifest" or "manifest=true". Unknown or
+// non-boolean parameters are left untouched for other consumers.
+func (p *patcher) parseArgs(args []string) {
+	for _, arg := range args {
+		key, value := arg, "true"
+		if idx := strings.Index(arg, "="); idx >= 0 {
+			key, value = arg[:idx], arg[idx+1:]
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "manifest":
+			p.manifest = b
+		case "no_field_packing":
+			p.noFieldPacking = b
+		}
+	}
+}
+
 func (p *patcher) buildTemplates() error {
 	m := p.utils.BuildFuncMap()
 	m["ReorderStructFields"] = p.reorderStructFields
@@ -75,10 +141,12 @@ func (p *patcher) buildTemplates() error {
 }
 
 func (p *patcher) patch(req *plugin.Request) (patches []*plugin.Generated, err error) {
+	p.parseArgs(req.PluginParameters)
 	p.buildTemplates()
 	var buf strings.Builder
 
 	protection := make(map[string]*plugin.Generated)
+	var entries []manifestEntry
 
 	for ast := range req.AST.DepthFirstSearch() {
 		scope, err := p.utils.BuildScope(ast)
@@ -125,6 +193,29 @@ func (p *patcher) patch(req *plugin.Request) (patches []*plugin.Generated, err e
 			Name:    &target,
 		})
 
+		if p.manifest {
+			idl, err := ioutil.ReadFile(ast.Filename)
+			if err != nil {
+				return nil, fmt.Errorf("read %q: %w", ast.Filename, err)
+			}
+			// Hash the gofmt'd bytes, not the raw template output, so the
+			// manifest's GoSha256 matches what downstream tooling writes to
+			// disk even though the patch Content here is left unformatted,
+			// as it always was.
+			formatted, ferr := format.Source([]byte(buf.String()))
+			if ferr != nil {
+				return nil, fmt.Errorf("format %q: %w", target, ferr)
+			}
+			entries = append(entries, manifestEntry{
+				OutputPath:   target,
+				Source:       ast.Filename,
+				IDLSha256:    sha256Hex(idl),
+				GoSha256:     sha256Hex(formatted),
+				KitexVersion: kitex.Version,
+				Imports:      data.Imports,
+			})
+		}
+
 		if p.copyIDL {
 			content, err := ioutil.ReadFile(ast.Filename)
 			if err != nil {
@@ -137,6 +228,20 @@ func (p *patcher) patch(req *plugin.Request) (patches []*plugin.Generated, err e
 			})
 		}
 	}
+
+	if p.manifest && len(entries) > 0 {
+		doc := manifest{Module: p.module, Files: entries}
+		content, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s: %w", manifestFileName, err)
+		}
+		path := filepath.Join(req.OutputPath, manifestFileName)
+		patches = append(patches, &plugin.Generated{
+			Content: string(content),
+			Name:    &path,
+		})
+	}
+
 	return
 }
 
@@ -160,7 +265,21 @@ func (p *patcher) filterBase(ast *parser.Thrift) interface{} {
 	}{Requests: req, Responses: res}
 }
 
+// reorderStructFields orders the Go struct fields generated for a Thrift
+// struct/union/exception. Field IDs and wire order are untouched; only the Go
+// declaration order changes. By default it packs fields by size class to
+// minimize padding; the "no_field_packing" plugin parameter falls back to the
+// legacy fixed-length-first ordering.
 func (p *patcher) reorderStructFields(fields []*parser.Field) ([]*parser.Field, error) {
+	if p.noFieldPacking {
+		return p.reorderStructFieldsLegacy(fields)
+	}
+	return p.packStructFields(fields), nil
+}
+
+// reorderStructFieldsLegacy is the original two-bucket split: fixed-length
+// fields first, then everything else, each in original IDL order.
+func (p *patcher) reorderStructFieldsLegacy(fields []*parser.Field) ([]*parser.Field, error) {
 	fixedLengthFields := make(map[*parser.Field]bool, len(fields))
 	for _, field := range fields {
 		ok, err := p.utils.IsFixedLengthType(field.Type)
@@ -185,6 +304,52 @@ func (p *patcher) reorderStructFields(fields []*parser.Field) ([]*parser.Field,
 	return sortedFields, nil
 }
 
+// packStructFields orders fields by descending Go-level alignment, breaking
+// ties by descending size and finally by original IDL order, so the emitted
+// struct carries as little padding as the field set allows when every field
+// is a base type or a word-sized reference. Typedef'd and enum scalar fields
+// are ordered as word-sized (see fieldSizeAndAlign), so a smaller typedef'd
+// scalar can still leave padding behind it.
+func (p *patcher) packStructFields(fields []*parser.Field) []*parser.Field {
+	sortedFields := make([]*parser.Field, len(fields))
+	copy(sortedFields, fields)
+
+	sort.SliceStable(sortedFields, func(i, j int) bool {
+		si, ai := fieldSizeAndAlign(sortedFields[i].Type)
+		sj, aj := fieldSizeAndAlign(sortedFields[j].Type)
+		if ai != aj {
+			return ai > aj
+		}
+		return si > sj
+	})
+
+	return sortedFields
+}
+
+// fieldSizeAndAlign returns the Go-level size and alignment used to order a
+// struct field. Variable-length types (strings, maps, slices, struct
+// references) are represented by their Go header/pointer word: they are all
+// aligned to the pointer size regardless of their runtime contents.
+func fieldSizeAndAlign(t *parser.Type) (size, align int) {
+	switch t.Name {
+	case "bool", "byte", "i8":
+		return 1, 1
+	case "i16":
+		return 2, 2
+	case "i32":
+		return 4, 4
+	case "i64", "double":
+		return 8, 8
+	default:
+		// string, binary, list, set, map, and struct/union/exception
+		// references all lower to a Go slice header, map header, pointer, or
+		// string header, each word-aligned. Typedef and enum names are not
+		// resolved to their underlying type and land here as well, even when
+		// that type is a smaller scalar.
+		return 8, 8
+	}
+}
+
 func (p *patcher) filterStdLib(imports map[string]string) {
 	// remove std libs and thrift to prevent duplicate import.
 	prefix := p.module + "/"
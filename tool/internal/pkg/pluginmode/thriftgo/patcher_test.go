@@ -0,0 +1,75 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package thriftgo
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+func TestPackStructFieldsOrdersByAlignment(t *testing.T) {
+	fields := []*parser.Field{
+		{Name: "A", Type: &parser.Type{Name: "bool"}},
+		{Name: "B", Type: &parser.Type{Name: "i64"}},
+		{Name: "C", Type: &parser.Type{Name: "i16"}},
+		{Name: "D", Type: &parser.Type{Name: "string"}},
+		{Name: "E", Type: &parser.Type{Name: "i64"}},
+		{Name: "F", Type: &parser.Type{Name: "bool"}},
+	}
+
+	p := &patcher{}
+	packed := p.packStructFields(fields)
+
+	want := []string{"B", "D", "E", "C", "A", "F"}
+	for i, f := range packed {
+		if f.Name != want[i] {
+			t.Fatalf("packed[%d] = %s, want %s", i, f.Name, want[i])
+		}
+	}
+}
+
+// TestPackStructFieldsShrinksStruct mirrors the field mix of a struct built
+// from fields and checks that the Go struct declared in packStructFields
+// order is smaller than the one declared in the legacy fixed-length-first
+// order, for a synthetic Thrift struct mixing int64, bool, int16, and string
+// fields.
+func TestPackStructFieldsShrinksStruct(t *testing.T) {
+	// legacy order: fixed-length fields first in original IDL order, then
+	// the variable-length field, as produced by reorderStructFieldsLegacy.
+	type legacy struct {
+		A bool
+		B int64
+		C int16
+		E int64
+		F bool
+		D string
+	}
+
+	// packed order: descending alignment/size, as produced by packStructFields.
+	type packed struct {
+		B int64
+		D string
+		E int64
+		C int16
+		A bool
+		F bool
+	}
+
+	if got, want := unsafe.Sizeof(packed{}), unsafe.Sizeof(legacy{}); got >= want {
+		t.Fatalf("packed struct is %d bytes, legacy struct is %d bytes: packing did not shrink the struct", got, want)
+	}
+}